@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/tools/txtar"
+)
+
+// writeTxtarArchive walks dir and writes its files as a single txtar
+// archive at archivePath. File names in the archive are slash-separated
+// paths relative to dir, so the archive round-trips with extractTxtar.
+func writeTxtarArchive(dir, archivePath string) error {
+	var files []txtar.File
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, txtar.File{
+			Name: filepath.ToSlash(rel),
+			Data: data,
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	archive := &txtar.Archive{Files: files}
+	return os.WriteFile(archivePath, txtar.Format(archive), 0644)
+}
+
+// extractTxtar materializes the txtar archive at archivePath into dir,
+// recreating each file's directory structure. It is the inverse of
+// writeTxtarArchive.
+func extractTxtar(archivePath, dir string) error {
+	archive, err := txtar.ParseFile(archivePath)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range archive.Files {
+		dst := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dst, f.Data, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTxtarRoundTrip(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "gopkgcp-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	srcDir := filepath.Join(tmpDir, "src")
+	if err := os.MkdirAll(filepath.Join(srcDir, "responses"), 0755); err != nil {
+		t.Fatalf("failed to create src dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "go.mod"), []byte("module example.com/pkg\n"), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "responses", "responses.go"), []byte("package responses\n"), 0644); err != nil {
+		t.Fatalf("failed to write responses.go: %v", err)
+	}
+
+	archivePath := filepath.Join(tmpDir, "out.txtar")
+	if err := writeTxtarArchive(srcDir, archivePath); err != nil {
+		t.Fatalf("writeTxtarArchive failed: %v", err)
+	}
+
+	dstDir := filepath.Join(tmpDir, "dst")
+	if err := extractTxtar(archivePath, dstDir); err != nil {
+		t.Fatalf("extractTxtar failed: %v", err)
+	}
+
+	for _, rel := range []string{"go.mod", filepath.Join("responses", "responses.go")} {
+		want, err := os.ReadFile(filepath.Join(srcDir, rel))
+		if err != nil {
+			t.Fatalf("reading source %s: %v", rel, err)
+		}
+		got, err := os.ReadFile(filepath.Join(dstDir, rel))
+		if err != nil {
+			t.Fatalf("reading extracted %s: %v", rel, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s = %q, want %q", rel, got, want)
+		}
+	}
+}
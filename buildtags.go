@@ -0,0 +1,110 @@
+package main
+
+import (
+	"go/build"
+	"strings"
+)
+
+// DroppedFile records a file that was excluded from the extracted tree
+// because it didn't satisfy the active build constraints.
+type DroppedFile struct {
+	// Path is the source path of the dropped file.
+	Path string
+	// Reason explains why the file was dropped.
+	Reason string
+}
+
+// buildSelector decides whether a Go source file should be copied into
+// the extracted tree based on its //go:build line and GOOS/GOARCH
+// filename suffixes. A file is kept if it satisfies any of the
+// selector's tag sets, which supports -tags-union.
+type buildSelector struct {
+	contexts []*build.Context
+}
+
+// newBuildSelector builds a selector for the given goos/goarch (empty
+// means "use the running toolchain's default") and one or more tag
+// sets. Pass a single tag set for the common case; pass several to keep
+// files matching any of them (-tags-union).
+func newBuildSelector(goos, goarch string, tagSets [][]string) *buildSelector {
+	if len(tagSets) == 0 {
+		tagSets = [][]string{nil}
+	}
+
+	sel := &buildSelector{}
+	for _, tags := range tagSets {
+		ctx := build.Default
+		if goos != "" {
+			ctx.GOOS = goos
+		}
+		if goarch != "" {
+			ctx.GOARCH = goarch
+		}
+		ctx.BuildTags = tags
+		sel.contexts = append(sel.contexts, &ctx)
+	}
+	return sel
+}
+
+// matches reports whether the file dir/name satisfies any of the
+// selector's build contexts.
+func (s *buildSelector) matches(dir, name string) bool {
+	for _, ctx := range s.contexts {
+		if ok, err := ctx.MatchFile(dir, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTagSets parses the -tags flag value into one or more tag sets.
+// Without -tags-union, tagsFlag is a single comma-separated tag set.
+// With -tags-union, tagsFlag is one or more semicolon-separated groups,
+// each a comma-separated tag set; a file is kept if it matches any
+// group.
+func parseTagSets(tagsFlag string, union bool) [][]string {
+	if tagsFlag == "" {
+		return [][]string{nil}
+	}
+	if !union {
+		return [][]string{splitTags(tagsFlag)}
+	}
+
+	var sets [][]string
+	for _, group := range strings.Split(tagsFlag, ";") {
+		if group = strings.TrimSpace(group); group != "" {
+			sets = append(sets, splitTags(group))
+		}
+	}
+	if len(sets) == 0 {
+		sets = [][]string{nil}
+	}
+	return sets
+}
+
+// flattenTagSets returns the deduplicated union of every tag across
+// tagSets, for passing to the dependency resolver so it considers
+// imports reachable under any of the sets.
+func flattenTagSets(tagSets [][]string) []string {
+	seen := make(map[string]bool)
+	var all []string
+	for _, set := range tagSets {
+		for _, tag := range set {
+			if tag != "" && !seen[tag] {
+				seen[tag] = true
+				all = append(all, tag)
+			}
+		}
+	}
+	return all
+}
+
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseTagSets(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     string
+		union    bool
+		expected [][]string
+	}{
+		{"no tags", "", false, [][]string{nil}},
+		{"single set", "linux,amd64", false, [][]string{{"linux", "amd64"}}},
+		{"union of two sets", "linux,amd64;darwin,arm64", true, [][]string{{"linux", "amd64"}, {"darwin", "arm64"}}},
+		{"union with blank group", "linux,amd64;;darwin", true, [][]string{{"linux", "amd64"}, {"darwin"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseTagSets(tt.tags, tt.union)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("parseTagSets(%q, %v) = %v, want %v", tt.tags, tt.union, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildSelectorMatchesGOOSSuffix(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "gopkgcp-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, name := range []string{"main.go", "main_windows.go", "main_linux.go"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("package main\n"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	selector := newBuildSelector("linux", "amd64", parseTagSets("", false))
+
+	if !selector.matches(tmpDir, "main.go") {
+		t.Errorf("main.go should match GOOS=linux")
+	}
+	if !selector.matches(tmpDir, "main_linux.go") {
+		t.Errorf("main_linux.go should match GOOS=linux")
+	}
+	if selector.matches(tmpDir, "main_windows.go") {
+		t.Errorf("main_windows.go should not match GOOS=linux")
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Package describes a single Go package discovered while resolving the
+// dependency graph rooted at the requested pattern.
+type Package struct {
+	// PkgPath is the package's import path.
+	PkgPath string
+	// Dir is the package's directory on disk.
+	Dir string
+	// ModulePath is the path of the module the package belongs to, or
+	// empty if the package has no module (e.g. a GOPATH package).
+	ModulePath string
+	// ModuleVersion is the version of the module the package belongs
+	// to, or empty if the package has no module or belongs to the
+	// main module.
+	ModuleVersion string
+	// ModuleDir is the root directory of the module the package
+	// belongs to, or empty if the package has no module.
+	ModuleDir string
+}
+
+// DependencyResolver discovers the transitive set of packages a pattern
+// depends on. packagesResolver is the default implementation; other
+// implementations (e.g. shelling out to goda) can satisfy the same
+// interface.
+type DependencyResolver interface {
+	Resolve(pattern string) ([]Package, error)
+}
+
+// packagesResolver resolves dependencies using golang.org/x/tools/go/packages,
+// which gives build-tag-aware, overlay-capable discovery equivalent to
+// what the Go toolchain itself uses.
+type packagesResolver struct {
+	// moduleOnly restricts Resolve to packages belonging to the same
+	// module as the root package. When false, all transitive
+	// dependencies (including external modules and the standard
+	// library) are returned.
+	moduleOnly bool
+
+	// dir, if set, is the working directory packages.Load resolves
+	// pattern from (e.g. a module fetched from the proxy into a temp
+	// dir rather than the current module).
+	dir string
+
+	// env, if set, is appended to the process environment when
+	// resolving (e.g. GOOS/GOARCH overrides).
+	env []string
+
+	// buildFlags, if set, is passed through to the underlying `go`
+	// command (e.g. ["-tags", "linux,amd64"]).
+	buildFlags []string
+}
+
+// ResolverOption configures a DependencyResolver returned by
+// newPackagesResolver.
+type ResolverOption func(*packagesResolver)
+
+// WithDir resolves patterns relative to dir instead of the current
+// working directory.
+func WithDir(dir string) ResolverOption {
+	return func(r *packagesResolver) { r.dir = dir }
+}
+
+// WithEnv appends env to the process environment used to resolve
+// patterns, so GOOS/GOARCH overrides are honored the same way they are
+// for file selection.
+func WithEnv(env []string) ResolverOption {
+	return func(r *packagesResolver) { r.env = env }
+}
+
+// WithBuildFlags passes flags (e.g. ["-tags", "linux,amd64"]) through to
+// the underlying `go` command so build-tag-only imports are discovered
+// (or excluded) consistently with file selection.
+func WithBuildFlags(flags []string) ResolverOption {
+	return func(r *packagesResolver) { r.buildFlags = flags }
+}
+
+// newPackagesResolver returns a DependencyResolver backed by
+// golang.org/x/tools/go/packages.
+func newPackagesResolver(moduleOnly bool, opts ...ResolverOption) DependencyResolver {
+	r := &packagesResolver{moduleOnly: moduleOnly}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *packagesResolver) Resolve(pattern string) ([]Package, error) {
+	cfg := &packages.Config{
+		Dir: r.dir,
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports |
+			packages.NeedDeps | packages.NeedModule,
+		BuildFlags: r.buildFlags,
+	}
+	if len(r.env) > 0 {
+		cfg.Env = append(os.Environ(), r.env...)
+	}
+
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", pattern, err)
+	}
+
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, fmt.Errorf("errors loading %s (see above)", pattern)
+	}
+
+	var rootModule string
+	if len(pkgs) > 0 && pkgs[0].Module != nil {
+		rootModule = pkgs[0].Module.Path
+	}
+
+	seen := make(map[string]bool)
+	var result []Package
+
+	var visit func(pkg *packages.Package)
+	visit = func(pkg *packages.Package) {
+		if seen[pkg.PkgPath] {
+			return
+		}
+		seen[pkg.PkgPath] = true
+
+		modulePath := ""
+		if pkg.Module != nil {
+			modulePath = pkg.Module.Path
+		}
+
+		if !r.moduleOnly || modulePath == rootModule {
+			dir := ""
+			if len(pkg.GoFiles) > 0 {
+				dir = dirOf(pkg.GoFiles[0])
+			}
+
+			moduleVersion, moduleDir := "", ""
+			if pkg.Module != nil {
+				moduleVersion = pkg.Module.Version
+				moduleDir = pkg.Module.Dir
+			}
+
+			result = append(result, Package{
+				PkgPath:       pkg.PkgPath,
+				Dir:           dir,
+				ModulePath:    modulePath,
+				ModuleVersion: moduleVersion,
+				ModuleDir:     moduleDir,
+			})
+		}
+
+		for _, imp := range pkg.Imports {
+			visit(imp)
+		}
+	}
+
+	for _, pkg := range pkgs {
+		visit(pkg)
+	}
+
+	return result, nil
+}
+
+// dirOf returns the directory containing file.
+func dirOf(file string) string {
+	if idx := strings.LastIndexByte(file, '/'); idx >= 0 {
+		return file[:idx]
+	}
+	return "."
+}
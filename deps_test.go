@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestDirOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		expected string
+	}{
+		{"file in nested dir", "/home/user/project/responses/responses.go", "/home/user/project/responses"},
+		{"file at root", "main.go", "."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := dirOf(tt.file)
+			if result != tt.expected {
+				t.Errorf("dirOf(%q) = %q, want %q", tt.file, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// inlineExternalDeps copies every external package in deps (one whose
+// module differs from sourceModulePath) into
+// <outputDir>/internal/third_party/<modulepath>_<version>/..., rewrites
+// every import of the inlined modules across outputDir to the new
+// internal path under targetModulePath (the module path the extracted
+// tree will have once written, which may differ from sourceModulePath
+// when -mod is also given), and drops the corresponding require lines
+// from outputDir/go.mod. allowGlobs/denyGlobs are comma-separated
+// path.Match globs matched against a dependency's module path; an empty
+// allowGlobs means "inline everything not denied". selector, if
+// non-nil, is applied to inlined files the same way it is to the main
+// module's files, so -tags/-goos/-goarch narrowing is consistent across
+// the whole extracted tree.
+func inlineExternalDeps(deps []Package, sourceModulePath, targetModulePath, outputDir, allowGlobs, denyGlobs string, selector *buildSelector, verbose bool) ([]DroppedFile, error) {
+	newPrefixes := make(map[string]string)
+	licensed := make(map[string]bool)
+	var allDropped []DroppedFile
+
+	for _, dep := range deps {
+		if dep.ModulePath == "" || dep.ModulePath == sourceModulePath {
+			continue
+		}
+		if !shouldInlineModule(dep.ModulePath, allowGlobs, denyGlobs) {
+			if verbose {
+				fmt.Printf("Not inlining (excluded by -inline-allow/-inline-deny): %s\n", dep.ModulePath)
+			}
+			continue
+		}
+
+		thirdPartyDir := dep.ModulePath
+		if dep.ModuleVersion != "" {
+			// "@" is not a valid import path character, so use "_" to
+			// separate the module path from its version.
+			thirdPartyDir += "_" + dep.ModuleVersion
+		}
+		moduleDestRoot := filepath.Join(outputDir, "internal", "third_party", filepath.FromSlash(thirdPartyDir))
+		newPrefixes[dep.ModulePath] = path.Join(targetModulePath, "internal", "third_party", thirdPartyDir)
+
+		relPath := strings.TrimPrefix(filepath.ToSlash(strings.TrimPrefix(dep.Dir, dep.ModuleDir)), "/")
+		dst := filepath.Join(moduleDestRoot, filepath.FromSlash(relPath))
+
+		if verbose {
+			fmt.Printf("Inlining: %s -> %s\n", dep.PkgPath, dst)
+		}
+		dropped, err := copyDir(dep.Dir, dst, selector)
+		allDropped = append(allDropped, dropped...)
+		if err != nil {
+			return allDropped, fmt.Errorf("inlining %s: %w", dep.PkgPath, err)
+		}
+
+		// dep.Dir may be the dependency's own module root (when the
+		// inlined package is the module's root package); its go.mod/go.sum
+		// would otherwise nest a second module inside the output tree and
+		// make this subtree unbuildable.
+		os.Remove(filepath.Join(dst, "go.mod"))
+		os.Remove(filepath.Join(dst, "go.sum"))
+
+		if !licensed[dep.ModulePath] {
+			licensed[dep.ModulePath] = true
+			if err := copyModuleLicense(dep.ModuleDir, moduleDestRoot); err != nil {
+				return allDropped, fmt.Errorf("copying LICENSE for %s: %w", dep.ModulePath, err)
+			}
+		}
+	}
+
+	if len(newPrefixes) == 0 {
+		return allDropped, nil
+	}
+
+	inlinedModules := make([]string, 0, len(newPrefixes))
+	for oldModule := range newPrefixes {
+		inlinedModules = append(inlinedModules, oldModule)
+	}
+	sort.Strings(inlinedModules)
+
+	for _, oldModule := range inlinedModules {
+		if err := rewriteModuleImports(outputDir, oldModule, newPrefixes[oldModule]); err != nil {
+			return allDropped, fmt.Errorf("rewriting imports for %s: %w", oldModule, err)
+		}
+	}
+
+	return allDropped, dropGoModRequires(filepath.Join(outputDir, "go.mod"), inlinedModules)
+}
+
+// shouldInlineModule reports whether modulePath should be inlined given
+// the -inline-allow/-inline-deny glob lists.
+func shouldInlineModule(modulePath, allowGlobs, denyGlobs string) bool {
+	if matchesAnyGlob(modulePath, denyGlobs) {
+		return false
+	}
+	if allowGlobs == "" {
+		return true
+	}
+	return matchesAnyGlob(modulePath, allowGlobs)
+}
+
+// matchesAnyGlob reports whether value matches any comma-separated
+// path.Match glob in globsCSV.
+func matchesAnyGlob(value, globsCSV string) bool {
+	for _, glob := range strings.Split(globsCSV, ",") {
+		glob = strings.TrimSpace(glob)
+		if glob == "" {
+			continue
+		}
+		if ok, _ := path.Match(glob, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// copyModuleLicense copies moduleDir/LICENSE to destRoot/LICENSE if it
+// exists.
+func copyModuleLicense(moduleDir, destRoot string) error {
+	src := filepath.Join(moduleDir, "LICENSE")
+	if _, err := os.Stat(src); err != nil {
+		return nil
+	}
+	return copyFile(src, filepath.Join(destRoot, "LICENSE"))
+}
+
+// rewriteModuleImports rewrites every import of oldModule (and its
+// subpackages) across the Go files in dir to newModule.
+func rewriteModuleImports(dir, oldModule, newModule string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".go") {
+			return nil
+		}
+		return rewriteGoFileImports(path, oldModule, newModule, info.Mode())
+	})
+}
+
+// dropGoModRequires removes the require directives for modulePaths from
+// the go.mod at goModPath, if present.
+func dropGoModRequires(goModPath string, modulePaths []string) error {
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	mf, err := modfile.Parse(goModPath, data, nil)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", goModPath, err)
+	}
+
+	changed := false
+	for _, req := range mf.Require {
+		for _, modPath := range modulePaths {
+			if req.Mod.Path == modPath {
+				if err := mf.DropRequire(modPath); err != nil {
+					return err
+				}
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	mf.Cleanup()
+	out, err := mf.Format()
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(goModPath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(goModPath, out, info.Mode())
+}
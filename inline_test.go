@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestShouldInlineModule(t *testing.T) {
+	tests := []struct {
+		name       string
+		modulePath string
+		allowGlobs string
+		denyGlobs  string
+		expected   bool
+	}{
+		{"no globs inlines everything", "github.com/foo/bar", "", "", true},
+		{"allow matches", "github.com/foo/bar", "github.com/foo/*", "", true},
+		{"allow does not match", "github.com/foo/bar", "github.com/other/*", "", false},
+		{"deny overrides allow", "github.com/foo/bar", "github.com/foo/*", "github.com/foo/bar", false},
+		{"deny without allow", "github.com/foo/bar", "", "github.com/foo/*", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := shouldInlineModule(tt.modulePath, tt.allowGlobs, tt.denyGlobs)
+			if result != tt.expected {
+				t.Errorf("shouldInlineModule(%q, %q, %q) = %v, want %v", tt.modulePath, tt.allowGlobs, tt.denyGlobs, result, tt.expected)
+			}
+		})
+	}
+}
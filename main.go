@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"flag"
 	"fmt"
 	"io"
@@ -18,23 +17,65 @@ func main() {
 	moduleOnly := flag.Bool("module-only", true, "Only extract packages from the same module (exclude external deps)")
 	modName := flag.String("mod", "", "Override module name in extracted files (e.g., github.com/myorg/myproject)")
 	verbose := flag.Bool("v", false, "Verbose output")
+	archivePath := flag.String("archive", "", "Also write the extracted packages as a single txtar archive at this path")
+	fromTxtar := flag.String("from-txtar", "", "Materialize a txtar archive (written by -archive) into -o instead of extracting a package")
+	inlineExternal := flag.Bool("inline-external", false, "Copy external dependencies into internal/third_party instead of leaving them as go.mod requires")
+	inlineAllow := flag.String("inline-allow", "", "Comma-separated path.Match globs of external module paths to inline (default: all, subject to -inline-deny)")
+	inlineDeny := flag.String("inline-deny", "", "Comma-separated path.Match globs of external module paths to never inline")
+	tags := flag.String("tags", "", "Comma-separated build tags used to select files; with -tags-union, semicolon-separated groups of tags")
+	goos := flag.String("goos", "", "GOOS to select files for (default: the running toolchain's GOOS)")
+	goarch := flag.String("goarch", "", "GOARCH to select files for (default: the running toolchain's GOARCH)")
+	tagsUnion := flag.Bool("tags-union", false, "Keep files matching ANY of the semicolon-separated -tags groups, instead of requiring a single tag set")
 	flag.Parse()
 
+	if *fromTxtar != "" {
+		if *output == "" {
+			fmt.Fprintf(os.Stderr, "Usage: gopkgcp -from-txtar <archive> -o <dir>\n")
+			os.Exit(1)
+		}
+		if err := extractTxtar(*fromTxtar, *output); err != nil {
+			fmt.Fprintf(os.Stderr, "Error extracting txtar archive: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Extracted %s to %s\n", *fromTxtar, *output)
+		return
+	}
+
 	if *pkg == "" || *output == "" {
 		fmt.Fprintf(os.Stderr, "Usage: gopkgcp -pkg <package> -o <dir>\n")
 		fmt.Fprintf(os.Stderr, "\nExample:\n")
 		fmt.Fprintf(os.Stderr, "  gopkgcp -pkg ./responses -o ./extracted\n")
+		fmt.Fprintf(os.Stderr, "  gopkgcp -pkg github.com/openai/openai-go/v3@latest/responses -mod github.com/myorg/responses -o ./responses\n")
 		fmt.Fprintf(os.Stderr, "\nFlags:\n")
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
 
-	// Get current module path
-	modulePath, moduleDir, err := getModuleInfo()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error getting module info: %v\n", err)
-		fmt.Fprintf(os.Stderr, "Make sure you're running this from a Go module directory\n")
-		os.Exit(1)
+	// Get the module path and directory to extract from. A -pkg value
+	// containing "@" names a remote module and version to fetch from
+	// the module proxy instead of the current module.
+	var modulePath, moduleDir string
+	var err error
+	if remoteModule, version, subpath, isRemote := parseRemotePkg(*pkg); isRemote {
+		if *verbose {
+			fmt.Printf("Fetching %s@%s from module proxy\n", remoteModule, version)
+		}
+		var cleanup func()
+		moduleDir, cleanup, err = fetchRemoteModule(remoteModule, version)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching module: %v\n", err)
+			os.Exit(1)
+		}
+		defer cleanup()
+		modulePath = remoteModule
+		*pkg = modulePath + subpath
+	} else {
+		modulePath, moduleDir, err = getModuleInfo()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting module info: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Make sure you're running this from a Go module directory\n")
+			os.Exit(1)
+		}
 	}
 
 	if *verbose {
@@ -42,21 +83,40 @@ func main() {
 		fmt.Printf("Module dir: %s\n", moduleDir)
 	}
 
-	// Run goda to get dependencies
-	selector := ":all"
-	if *moduleOnly {
-		selector = ":mod"
+	// Build a build-tag/GOOS/GOARCH file selector if narrowing was requested,
+	// and feed the same constraints to dependency resolution so packages.Load
+	// doesn't report imports that only appear on excluded platforms.
+	tagSets := parseTagSets(*tags, *tagsUnion)
+	var selector *buildSelector
+	resolverOpts := []ResolverOption{WithDir(moduleDir)}
+	if *tags != "" || *goos != "" || *goarch != "" {
+		selector = newBuildSelector(*goos, *goarch, tagSets)
+
+		if union := flattenTagSets(tagSets); len(union) > 0 {
+			resolverOpts = append(resolverOpts, WithBuildFlags([]string{"-tags", strings.Join(union, ",")}))
+		}
+
+		var env []string
+		if *goos != "" {
+			env = append(env, "GOOS="+*goos)
+		}
+		if *goarch != "" {
+			env = append(env, "GOARCH="+*goarch)
+		}
+		if len(env) > 0 {
+			resolverOpts = append(resolverOpts, WithEnv(env))
+		}
 	}
 
-	godaExpr := *pkg + selector
+	// Resolve the transitive dependency graph rooted at pkg.
+	resolver := newPackagesResolver(*moduleOnly, resolverOpts...)
 	if *verbose {
-		fmt.Printf("Running: goda list %s\n", godaExpr)
+		fmt.Printf("Resolving dependencies for %s (module-only=%v)\n", *pkg, *moduleOnly)
 	}
 
-	deps, err := runGoda(godaExpr)
+	deps, err := resolver.Resolve(*pkg)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error running goda: %v\n", err)
-		fmt.Fprintf(os.Stderr, "Make sure goda is installed: go install github.com/loov/goda@latest\n")
+		fmt.Fprintf(os.Stderr, "Error resolving dependencies: %v\n", err)
 		os.Exit(1)
 	}
 
@@ -68,7 +128,7 @@ func main() {
 	if *verbose {
 		fmt.Printf("Found %d packages to extract:\n", len(deps))
 		for _, d := range deps {
-			fmt.Printf("  - %s\n", d)
+			fmt.Printf("  - %s\n", d.PkgPath)
 		}
 	}
 
@@ -80,17 +140,18 @@ func main() {
 
 	// Filter and copy packages
 	copied := 0
+	var allDropped []DroppedFile
 	for _, dep := range deps {
 		// Skip external dependencies if module-only
-		if !strings.HasPrefix(dep, modulePath) {
+		if !strings.HasPrefix(dep.PkgPath, modulePath) {
 			if *verbose {
-				fmt.Printf("Skipping external: %s\n", dep)
+				fmt.Printf("Skipping external: %s\n", dep.PkgPath)
 			}
 			continue
 		}
 
 		// Convert package path to relative directory
-		relPath := strings.TrimPrefix(dep, modulePath)
+		relPath := strings.TrimPrefix(dep.PkgPath, modulePath)
 		relPath = strings.TrimPrefix(relPath, "/")
 
 		srcDir := filepath.Join(moduleDir, relPath)
@@ -100,7 +161,9 @@ func main() {
 			fmt.Printf("Copying: %s -> %s\n", srcDir, dstDir)
 		}
 
-		if err := copyDir(srcDir, dstDir); err != nil {
+		dropped, err := copyDir(srcDir, dstDir, selector)
+		allDropped = append(allDropped, dropped...)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error copying %s: %v\n", relPath, err)
 			continue
 		}
@@ -134,6 +197,37 @@ func main() {
 		fmt.Printf("✓ Replaced module name with %s\n", *modName)
 	}
 
+	// Inline external dependencies into internal/third_party if requested.
+	if *inlineExternal {
+		targetModulePath := modulePath
+		if *modName != "" {
+			targetModulePath = *modName
+		}
+
+		if *verbose {
+			fmt.Printf("Resolving external dependencies to inline\n")
+		}
+		allDeps, err := newPackagesResolver(false, resolverOpts...).Resolve(*pkg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving external dependencies: %v\n", err)
+			os.Exit(1)
+		}
+		inlineDropped, err := inlineExternalDeps(allDeps, modulePath, targetModulePath, *output, *inlineAllow, *inlineDeny, selector, *verbose)
+		allDropped = append(allDropped, inlineDropped...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error inlining external dependencies: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Inlined external dependencies into internal/third_party\n")
+	}
+
+	if len(allDropped) > 0 {
+		fmt.Printf("Dropped %d files due to build constraints:\n", len(allDropped))
+		for _, d := range allDropped {
+			fmt.Printf("  - %s (%s)\n", d.Path, d.Reason)
+		}
+	}
+
 	// Run go mod tidy in output directory
 	if *verbose {
 		fmt.Printf("Running go mod tidy in %s\n", *output)
@@ -151,6 +245,18 @@ func main() {
 		fmt.Printf("✓ go mod tidy completed\n")
 	}
 
+	// Also pack the extracted tree into a single txtar archive if requested.
+	if *archivePath != "" {
+		if *verbose {
+			fmt.Printf("Writing txtar archive to %s\n", *archivePath)
+		}
+		if err := writeTxtarArchive(*output, *archivePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing txtar archive: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Wrote txtar archive to %s\n", *archivePath)
+	}
+
 	fmt.Printf("\n✓ Done! Your extracted package is ready at: %s\n", *output)
 }
 
@@ -174,73 +280,29 @@ func getModuleInfo() (modulePath string, moduleDir string, err error) {
 	return modulePath, moduleDir, nil
 }
 
-func runGoda(expr string) ([]string, error) {
-	// Try to find goda
-	godaPath, err := findGoda()
-	if err != nil {
-		return nil, err
-	}
-
-	cmd := exec.Command(godaPath, "list", expr)
-	out, err := cmd.Output()
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("goda failed: %s", string(exitErr.Stderr))
-		}
-		return nil, err
-	}
-
-	var deps []string
-	scanner := bufio.NewScanner(strings.NewReader(string(out)))
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line != "" && line != "ID" { // Skip header
-			deps = append(deps, line)
-		}
-	}
-
-	return deps, nil
-}
-
-func findGoda() (string, error) {
-	// Check if goda is in PATH
-	if path, err := exec.LookPath("goda"); err == nil {
-		return path, nil
-	}
-
-	// Check in GOPATH/bin
-	gopath := os.Getenv("GOPATH")
-	if gopath == "" {
-		home, _ := os.UserHomeDir()
-		gopath = filepath.Join(home, "go")
-	}
-
-	godaPath := filepath.Join(gopath, "bin", "goda")
-	if _, err := os.Stat(godaPath); err == nil {
-		return godaPath, nil
-	}
-
-	return "", fmt.Errorf("goda not found in PATH or %s", godaPath)
-}
-
-func copyDir(src, dst string) error {
+// copyDir copies src to dst, skipping directories and files per
+// shouldSkipDir/shouldCopyFile. When selector is non-nil, Go files that
+// don't satisfy its build constraints are dropped instead of copied and
+// reported in the returned slice.
+func copyDir(src, dst string, selector *buildSelector) ([]DroppedFile, error) {
 	// Get source info
 	srcInfo, err := os.Stat(src)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Create destination directory
 	if err := os.MkdirAll(dst, srcInfo.Mode()); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Read directory entries
 	entries, err := os.ReadDir(src)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	var dropped []DroppedFile
 	for _, entry := range entries {
 		srcPath := filepath.Join(src, entry.Name())
 		dstPath := filepath.Join(dst, entry.Name())
@@ -250,20 +312,32 @@ func copyDir(src, dst string) error {
 			if shouldSkipDir(entry.Name()) {
 				continue
 			}
-			if err := copyDir(srcPath, dstPath); err != nil {
-				return err
+			sub, err := copyDir(srcPath, dstPath, selector)
+			dropped = append(dropped, sub...)
+			if err != nil {
+				return dropped, err
 			}
 		} else {
 			// Only copy Go files and important files
-			if shouldCopyFile(entry.Name()) {
-				if err := copyFile(srcPath, dstPath); err != nil {
-					return err
-				}
+			if !shouldCopyFile(entry.Name()) {
+				continue
+			}
+
+			if selector != nil && strings.HasSuffix(entry.Name(), ".go") && !selector.matches(src, entry.Name()) {
+				dropped = append(dropped, DroppedFile{
+					Path:   srcPath,
+					Reason: "excluded by -tags/-goos/-goarch build constraints",
+				})
+				continue
+			}
+
+			if err := copyFile(srcPath, dstPath); err != nil {
+				return dropped, err
 			}
 		}
 	}
 
-	return nil
+	return dropped, nil
 }
 
 func shouldSkipDir(name string) bool {
@@ -297,42 +371,6 @@ func shouldCopyFile(name string) bool {
 	return false
 }
 
-func replaceModuleInFiles(dir, oldModule, newModule string) error {
-	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if info.IsDir() {
-			return nil
-		}
-
-		// Only process .go files and go.mod
-		name := info.Name()
-		if !strings.HasSuffix(name, ".go") && name != "go.mod" {
-			return nil
-		}
-
-		// Read file content
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return err
-		}
-
-		// Replace old module with new module
-		newContent := strings.ReplaceAll(string(content), oldModule, newModule)
-
-		// Only write if content changed
-		if newContent != string(content) {
-			if err := os.WriteFile(path, []byte(newContent), info.Mode()); err != nil {
-				return err
-			}
-		}
-
-		return nil
-	})
-}
-
 func copyFile(src, dst string) error {
 	srcFile, err := os.Open(src)
 	if err != nil {
@@ -345,7 +383,10 @@ func copyFile(src, dst string) error {
 		return err
 	}
 
-	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, srcInfo.Mode())
+	// Cache-sourced files (e.g. from GOMODCACHE) are read-only on disk;
+	// OR in the owner write bit so later passes (import rewriting) can
+	// still overwrite the copy.
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, srcInfo.Mode()|0200)
 	if err != nil {
 		return err
 	}
@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -126,7 +127,7 @@ func TestCopyDir(t *testing.T) {
 
 	// Copy directory
 	dstDir := filepath.Join(tmpDir, "dst")
-	if err := copyDir(srcDir, dstDir); err != nil {
+	if _, err := copyDir(srcDir, dstDir, nil); err != nil {
 		t.Fatalf("copyDir failed: %v", err)
 	}
 
@@ -205,26 +206,25 @@ func main() {
 		t.Errorf("go.mod = %q, want %q", string(goModResult), expectedGoMod)
 	}
 
-	// Verify .go file was updated
+	// Verify .go file was updated. The AST-based rewrite may re-sort
+	// imports within the block, so check content rather than exact
+	// byte-for-byte formatting.
 	goFileResult, err := os.ReadFile(goFilePath)
 	if err != nil {
 		t.Fatalf("failed to read main.go: %v", err)
 	}
-	expectedGoFile := `package main
-
-import (
-	"fmt"
-
-	"github.com/myorg/myproject/responses"
-	"github.com/myorg/myproject/internal/util"
-)
-
-func main() {
-	fmt.Println("hello")
-}
-`
-	if string(goFileResult) != expectedGoFile {
-		t.Errorf("main.go = %q, want %q", string(goFileResult), expectedGoFile)
+	goFileStr := string(goFileResult)
+	for _, want := range []string{
+		`"github.com/myorg/myproject/responses"`,
+		`"github.com/myorg/myproject/internal/util"`,
+		`fmt.Println("hello")`,
+	} {
+		if !strings.Contains(goFileStr, want) {
+			t.Errorf("main.go = %q, want it to contain %q", goFileStr, want)
+		}
+	}
+	if strings.Contains(goFileStr, oldModule) {
+		t.Errorf("main.go still references old module: %q", goFileStr)
 	}
 
 	// Verify .txt file was NOT modified
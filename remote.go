@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// parseRemotePkg splits a -pkg value of the form
+// "github.com/openai/openai-go/v3@v3.2.1/responses" into the module
+// path, version, and subpackage path. isRemote is false when pkg has no
+// "@" and should be treated as a local package pattern instead.
+func parseRemotePkg(pkg string) (modulePath, version, subpath string, isRemote bool) {
+	atIdx := -1
+	for i, c := range pkg {
+		if c == '@' {
+			atIdx = i
+			break
+		}
+	}
+	if atIdx < 0 {
+		return "", "", "", false
+	}
+
+	modulePath = pkg[:atIdx]
+	rest := pkg[atIdx+1:]
+
+	slashIdx := -1
+	for i, c := range rest {
+		if c == '/' {
+			slashIdx = i
+			break
+		}
+	}
+	if slashIdx < 0 {
+		return modulePath, rest, "", true
+	}
+	return modulePath, rest[:slashIdx], rest[slashIdx:], true
+}
+
+// downloadInfo mirrors the relevant fields of `go mod download -json`'s
+// output.
+type downloadInfo struct {
+	Path    string
+	Version string
+	Dir     string
+	Error   string
+}
+
+// fetchRemoteModule downloads modulePath@version (defaulting to
+// "latest" when version is empty) via the Go module proxy and returns
+// a writable copy of its extracted source. It shells out to `go mod
+// download`, so it honors GOPROXY, GONOPROXY, GOSUMDB, and GOMODCACHE
+// the same way the rest of the toolchain does.
+//
+// `go mod download` extracts into GOMODCACHE, which the toolchain
+// marks read-only, and resolving/copying directly from there is
+// fragile (go won't update go.sum in place, and transitive deps of
+// the fetched module aren't downloaded into the caller's module
+// graph). So the module is copied out into the writable scratch dir
+// before its directory is returned.
+func fetchRemoteModule(modulePath, version string) (dir string, cleanup func(), err error) {
+	if version == "" {
+		version = "latest"
+	}
+
+	scratchDir, err := os.MkdirTemp("", "gopkgcp-fetch")
+	if err != nil {
+		return "", nil, fmt.Errorf("creating scratch dir: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(scratchDir) }
+
+	initCmd := exec.Command("go", "mod", "init", "gopkgcp-fetch-scratch")
+	initCmd.Dir = scratchDir
+	if out, err := initCmd.CombinedOutput(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("go mod init: %w: %s", err, out)
+	}
+
+	downloadCmd := exec.Command("go", "mod", "download", "-json", modulePath+"@"+version)
+	downloadCmd.Dir = scratchDir
+	out, err := downloadCmd.Output()
+	if err != nil {
+		cleanup()
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", nil, fmt.Errorf("go mod download %s@%s: %s", modulePath, version, exitErr.Stderr)
+		}
+		return "", nil, fmt.Errorf("go mod download %s@%s: %w", modulePath, version, err)
+	}
+
+	var info downloadInfo
+	if err := json.Unmarshal(out, &info); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("parsing go mod download output: %w", err)
+	}
+	if info.Error != "" {
+		cleanup()
+		return "", nil, fmt.Errorf("downloading %s@%s: %s", modulePath, version, info.Error)
+	}
+
+	moduleDir := filepath.Join(scratchDir, "module")
+	if err := copyModuleCacheTree(info.Dir, moduleDir); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("copying %s@%s out of the module cache: %w", modulePath, version, err)
+	}
+
+	return moduleDir, cleanup, nil
+}
+
+// copyModuleCacheTree copies every file under src (a module cache
+// directory) to dst, unlike copyDir it copies everything verbatim
+// (including go.mod/go.sum, test files, etc.) since the caller needs
+// the complete module to resolve and extract packages from, not just
+// the subset gopkgcp would ship in its own output.
+func copyModuleCacheTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode()|0200)
+		}
+		return copyFile(path, target)
+	})
+}
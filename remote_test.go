@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestParseRemotePkg(t *testing.T) {
+	tests := []struct {
+		name         string
+		pkg          string
+		wantModule   string
+		wantVersion  string
+		wantSubpath  string
+		wantIsRemote bool
+	}{
+		{
+			name:         "module, version, and subpath",
+			pkg:          "github.com/openai/openai-go/v3@v3.2.1/responses",
+			wantModule:   "github.com/openai/openai-go/v3",
+			wantVersion:  "v3.2.1",
+			wantSubpath:  "/responses",
+			wantIsRemote: true,
+		},
+		{
+			name:         "module and version, no subpath",
+			pkg:          "github.com/openai/openai-go/v3@latest",
+			wantModule:   "github.com/openai/openai-go/v3",
+			wantVersion:  "latest",
+			wantSubpath:  "",
+			wantIsRemote: true,
+		},
+		{
+			name:         "local package pattern is not remote",
+			pkg:          "./responses",
+			wantIsRemote: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module, version, subpath, isRemote := parseRemotePkg(tt.pkg)
+			if isRemote != tt.wantIsRemote {
+				t.Fatalf("parseRemotePkg(%q) isRemote = %v, want %v", tt.pkg, isRemote, tt.wantIsRemote)
+			}
+			if !isRemote {
+				return
+			}
+			if module != tt.wantModule || version != tt.wantVersion || subpath != tt.wantSubpath {
+				t.Errorf("parseRemotePkg(%q) = (%q, %q, %q), want (%q, %q, %q)",
+					tt.pkg, module, version, subpath, tt.wantModule, tt.wantVersion, tt.wantSubpath)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// replaceModuleInFiles rewrites every import of oldModule (and its
+// subpackages) to newModule across dir, updating Go source via the AST
+// instead of doing a blanket text replace. go.mod is rewritten via
+// golang.org/x/mod/modfile so the module directive and any replace
+// targets stay well-formed.
+func replaceModuleInFiles(dir, oldModule, newModule string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		name := info.Name()
+		switch {
+		case strings.HasSuffix(name, ".go"):
+			return rewriteGoFileImports(path, oldModule, newModule, info.Mode())
+		case name == "go.mod":
+			return rewriteGoMod(path, oldModule, newModule, info.Mode())
+		}
+		return nil
+	})
+}
+
+// rewriteGoFileImports rewrites every import in the file at path whose
+// path is oldModule or a subpackage of it, preserving formatting and
+// file mode.
+func rewriteGoFileImports(path, oldModule, newModule string, mode os.FileMode) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	changed := false
+	for _, imp := range file.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		newPath, ok := rewriteImportPath(importPath, oldModule, newModule)
+		if !ok {
+			continue
+		}
+
+		if astutil.RewriteImport(fset, file, importPath, newPath) {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return fmt.Errorf("formatting %s: %w", path, err)
+	}
+
+	return os.WriteFile(path, buf.Bytes(), mode)
+}
+
+// rewriteImportPath reports whether importPath is oldModule or a
+// subpackage of it, and if so returns the equivalent path under
+// newModule, preserving the subpath suffix (e.g. "oldmod/v3/responses"
+// becomes "newmod/responses" when oldModule is "oldmod/v3" and
+// newModule is "newmod").
+func rewriteImportPath(importPath, oldModule, newModule string) (string, bool) {
+	if importPath == oldModule {
+		return newModule, true
+	}
+	if suffix := strings.TrimPrefix(importPath, oldModule+"/"); suffix != importPath {
+		return newModule + "/" + suffix, true
+	}
+	return "", false
+}
+
+// rewriteGoMod updates the module directive and any replace directives
+// in the go.mod at path that reference oldModule.
+func rewriteGoMod(path, oldModule, newModule string, mode os.FileMode) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	mf, err := modfile.Parse(path, data, nil)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	changed := false
+	if mf.Module != nil && mf.Module.Mod.Path == oldModule {
+		if err := mf.AddModuleStmt(newModule); err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	for _, r := range mf.Replace {
+		if r.Old.Path != oldModule {
+			continue
+		}
+		// DropReplace zeroes *r, so capture its fields first.
+		oldVers, newPath, newVers := r.Old.Version, r.New.Path, r.New.Version
+		if err := mf.DropReplace(r.Old.Path, oldVers); err != nil {
+			return err
+		}
+		if err := mf.AddReplace(newModule, oldVers, newPath, newVers); err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	mf.Cleanup()
+	out, err := mf.Format()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, out, mode)
+}
@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+func TestRewriteImportPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		importPath string
+		oldModule  string
+		newModule  string
+		wantPath   string
+		wantOK     bool
+	}{
+		{
+			name:       "exact module match",
+			importPath: "github.com/openai/openai-go/v3",
+			oldModule:  "github.com/openai/openai-go/v3",
+			newModule:  "github.com/myorg/responses",
+			wantPath:   "github.com/myorg/responses",
+			wantOK:     true,
+		},
+		{
+			name:       "subpackage with semver major suffix",
+			importPath: "github.com/openai/openai-go/v3/responses",
+			oldModule:  "github.com/openai/openai-go/v3",
+			newModule:  "github.com/myorg/responses",
+			wantPath:   "github.com/myorg/responses/responses",
+			wantOK:     true,
+		},
+		{
+			name:       "unrelated package is untouched",
+			importPath: "github.com/openai/openai-go/v3internal/util",
+			oldModule:  "github.com/openai/openai-go/v3",
+			newModule:  "github.com/myorg/responses",
+			wantOK:     false,
+		},
+		{
+			name:       "different module is untouched",
+			importPath: "fmt",
+			oldModule:  "github.com/openai/openai-go/v3",
+			newModule:  "github.com/myorg/responses",
+			wantOK:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPath, gotOK := rewriteImportPath(tt.importPath, tt.oldModule, tt.newModule)
+			if gotOK != tt.wantOK {
+				t.Fatalf("rewriteImportPath(%q, %q, %q) ok = %v, want %v", tt.importPath, tt.oldModule, tt.newModule, gotOK, tt.wantOK)
+			}
+			if gotOK && gotPath != tt.wantPath {
+				t.Errorf("rewriteImportPath(%q, %q, %q) = %q, want %q", tt.importPath, tt.oldModule, tt.newModule, gotPath, tt.wantPath)
+			}
+		})
+	}
+}